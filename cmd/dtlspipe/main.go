@@ -0,0 +1,34 @@
+// Command dtlspipe is the CLI entrypoint for operating a running tunnel,
+// starting with PSK rotation; the server/client tunnel commands live
+// alongside it here as they're added.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "psk":
+		err = runPSK(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: dtlspipe psk rotate [flags]")
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/boreevyuri/dtlspipe/util"
+)
+
+func runPSK(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: dtlspipe psk rotate [flags]")
+	}
+
+	switch args[0] {
+	case "rotate":
+		return runPSKRotate(args[1:])
+	default:
+		return fmt.Errorf("unknown psk subcommand %q", args[0])
+	}
+}
+
+// runPSKRotate appends a freshly generated key to the store at -file,
+// keeping whatever key was valid a moment ago alongside it for -overlap so
+// operators can roll keys without downtime.
+func runPSKRotate(args []string) error {
+	fs := flag.NewFlagSet("psk rotate", flag.ContinueOnError)
+	file := fs.String("file", "psk.store", "PSK store file to rotate")
+	length := fs.Int("length", 32, "length in bytes of the new key")
+	idLength := fs.Int("id-length", 8, "length in bytes of the new key's identity")
+	overlap := fs.Duration("overlap", 24*time.Hour, "how long the previously current key stays valid alongside the new one")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store, err := util.LoadPSKFile(*file)
+	if errors.Is(err, os.ErrNotExist) {
+		store = util.NewPSKStore()
+	} else if err != nil {
+		return fmt.Errorf("load psk file: %w", err)
+	}
+
+	entry, err := store.Rotate(*length, *idLength, *overlap)
+	if err != nil {
+		return fmt.Errorf("rotate psk: %w", err)
+	}
+
+	if err := store.SaveFile(*file); err != nil {
+		return fmt.Errorf("save psk file: %w", err)
+	}
+
+	fmt.Printf("added psk id=%x to %s (overlap window %s)\n", entry.ID, *file, *overlap)
+	return nil
+}
@@ -0,0 +1,139 @@
+// Package metrics turns util.SessionStats snapshots into a Prometheus
+// text-exposition endpoint, so a dtlspipe server is operable at scale
+// instead of being a black box that only prints to stderr.
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/boreevyuri/dtlspipe/util"
+)
+
+// Registry implements util.MetricsSink, aggregating per-session counters
+// into counters keyed by direction and a CIDR-bucketed address pair so
+// cardinality stays bounded no matter how many distinct peers connect.
+type Registry struct {
+	mu       sync.Mutex
+	sessions int64
+	counters map[metricKey]int64
+}
+
+type metricKey struct {
+	name       string
+	direction  string
+	localCIDR  string
+	remoteCIDR string
+}
+
+// NewRegistry returns an empty Registry ready to receive sessions via
+// ObserveSession.
+func NewRegistry() *Registry {
+	return &Registry{counters: make(map[metricKey]int64)}
+}
+
+// ObserveSession implements util.MetricsSink.
+func (r *Registry) ObserveSession(stats util.SessionStats) {
+	local := bucketCIDR(stats.LocalAddr)
+	remote := bucketCIDR(stats.RemoteAddr)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sessions++
+	r.add(metricKey{"bytes_total", "left_to_right", local, remote}, stats.BytesLeftToRight)
+	r.add(metricKey{"bytes_total", "right_to_left", local, remote}, stats.BytesRightToLeft)
+	r.add(metricKey{"packets_total", "left_to_right", local, remote}, stats.PacketsLeftToRight)
+	r.add(metricKey{"packets_total", "right_to_left", local, remote}, stats.PacketsRightToLeft)
+	r.add(metricKey{"stale_drops_total", "", local, remote}, stats.StaleDrops)
+	r.add(metricKey{"temp_errors_total", "", local, remote}, stats.TempErrors)
+	r.add(metricKey{"write_errors_total", "", local, remote}, stats.WriteErrors)
+}
+
+func (r *Registry) add(k metricKey, v int64) {
+	r.counters[k] += v
+}
+
+// Sessions returns the number of sessions observed so far.
+func (r *Registry) Sessions() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.sessions
+}
+
+// bucketCIDR reduces addr to a /24 (IPv4) or /64 (IPv6) network so
+// per-peer cardinality doesn't grow unbounded in the exported metrics.
+func bucketCIDR(addr net.Addr) string {
+	if addr == nil {
+		return "unknown"
+	}
+
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		host = addr.String()
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return "unknown"
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		mask := net.CIDRMask(24, 32)
+		return (&net.IPNet{IP: ip4.Mask(mask), Mask: mask}).String()
+	}
+
+	mask := net.CIDRMask(64, 128)
+	return (&net.IPNet{IP: ip.Mask(mask), Mask: mask}).String()
+}
+
+// render formats k as a Prometheus metric line prefix, e.g.
+// `dtlspipe_bytes_total{direction="left_to_right",local_cidr="10.0.0.0/24",remote_cidr="203.0.113.0/24"}`.
+func (k metricKey) render() string {
+	labels := []string{
+		fmt.Sprintf("local_cidr=%q", k.localCIDR),
+		fmt.Sprintf("remote_cidr=%q", k.remoteCIDR),
+	}
+	if k.direction != "" {
+		labels = append(labels, fmt.Sprintf("direction=%q", k.direction))
+	}
+
+	return fmt.Sprintf("dtlspipe_%s{%s}", k.name, strings.Join(labels, ","))
+}
+
+// Snapshot returns a point-in-time copy of the aggregated counters, keyed
+// by their rendered Prometheus metric line.
+func (r *Registry) Snapshot() map[string]int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make(map[string]int64, len(r.counters))
+	for k, v := range r.counters {
+		out[k.render()] = v
+	}
+
+	return out
+}
+
+// Handler returns an http.Handler serving the Prometheus text exposition
+// format, conventionally mounted at "/metrics".
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+		snap := r.Snapshot()
+		keys := make([]string, 0, len(snap))
+		for k := range snap {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			fmt.Fprintf(w, "%s %d\n", k, snap[k])
+		}
+	})
+}
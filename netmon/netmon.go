@@ -0,0 +1,164 @@
+// Package netmon watches the local machine's network interfaces and
+// addresses for changes (Wi-Fi <-> LTE handoff, VPN up/down, DHCP lease
+// renewal) and reports them on a channel so long-lived listeners and
+// sessions can react instead of waiting out their idle timeouts.
+package netmon
+
+import (
+	"net"
+	"sync"
+)
+
+// EventType identifies what changed.
+type EventType int
+
+const (
+	// LinkChange means an interface was added, removed, or changed
+	// operational state.
+	LinkChange EventType = iota
+	// AddrAdd means an address was assigned to an interface.
+	AddrAdd
+	// AddrDel means an address was removed from an interface.
+	AddrDel
+)
+
+// String returns a short, lower-case name for the event type.
+func (t EventType) String() string {
+	switch t {
+	case LinkChange:
+		return "link_change"
+	case AddrAdd:
+		return "addr_add"
+	case AddrDel:
+		return "addr_del"
+	default:
+		return "unknown"
+	}
+}
+
+// Event describes a single interface/address change.
+type Event struct {
+	Type EventType
+	// Iface is the interface name the event pertains to, when known.
+	Iface string
+	// Addr is the address added or removed. It is nil for LinkChange.
+	Addr net.Addr
+}
+
+// Watcher emits Events as the local network configuration changes.
+// Implementations are platform-specific: NewWatcher returns a netlink-based
+// watcher on Linux and a polling-based one elsewhere.
+type Watcher interface {
+	// Events returns the channel Event values are delivered on. It is
+	// closed when the Watcher is closed.
+	Events() <-chan Event
+	// Close stops the watcher and releases its resources.
+	Close() error
+}
+
+// HasAddr reports whether addr is currently bound to any local interface.
+// Callers use it after receiving an AddrDel (or on a timer) to decide
+// whether a listener's bound address is still valid.
+func HasAddr(addr net.IP) bool {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return false
+	}
+
+	for _, a := range addrs {
+		ipNet, ok := a.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if ipNet.IP.Equal(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addrSub is one AddrCanceller registration: the address it cares about
+// and the channel to close once that address is gone.
+type addrSub struct {
+	addr net.IP
+	ch   chan struct{}
+}
+
+// AddrCanceller watches one Watcher on behalf of many interested
+// addresses and closes each subscriber's channel once its address is no
+// longer bound to any local interface.
+//
+// A Watcher's Events() channel has exactly one reader side. Handing out a
+// per-call goroutine that ranges over it directly (as an earlier version
+// of this package did) doesn't work once more than one caller shares a
+// Watcher: a given event is delivered to exactly one of the ranging
+// goroutines, and it may not be the one whose address the event actually
+// concerns, silently dropping the notification the others needed.
+// AddrCanceller runs the single consuming goroutine itself and checks
+// every registered address on each AddrDel, regardless of which address
+// the event named, so no subscriber depends on winning a race for the
+// event that happens to name its own address.
+type AddrCanceller struct {
+	mu   sync.Mutex
+	subs map[int]addrSub
+	next int
+}
+
+// NewAddrCanceller starts watching w and returns an AddrCanceller ready
+// for Watch calls. It runs until w.Events() closes.
+func NewAddrCanceller(w Watcher) *AddrCanceller {
+	c := &AddrCanceller{subs: make(map[int]addrSub)}
+	go c.run(w)
+	return c
+}
+
+func (c *AddrCanceller) run(w Watcher) {
+	for ev := range w.Events() {
+		if ev.Type != AddrDel {
+			continue
+		}
+		c.checkAll()
+	}
+}
+
+// checkAll closes and drops every subscriber whose address is no longer
+// bound to any local interface.
+func (c *AddrCanceller) checkAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, sub := range c.subs {
+		if HasAddr(sub.addr) {
+			continue
+		}
+		close(sub.ch)
+		delete(c.subs, id)
+	}
+}
+
+// Watch registers addr and returns a channel that closes once addr is no
+// longer bound to any local interface, suitable as a
+// util.PairConnOptions.Cancel value so a session bound to addr can be
+// torn down immediately instead of waiting out its idle timeout.
+//
+// The caller must invoke the returned release func once it no longer
+// cares, typically on normal session teardown, or the subscription (and
+// its channel) outlives the session for no reason. Calling release after
+// the channel has already closed is a no-op.
+func (c *AddrCanceller) Watch(addr net.IP) (<-chan struct{}, func()) {
+	c.mu.Lock()
+	id := c.next
+	c.next++
+	ch := make(chan struct{})
+	c.subs[id] = addrSub{addr: addr, ch: ch}
+	c.mu.Unlock()
+
+	release := func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		delete(c.subs, id)
+	}
+
+	return ch, release
+}
@@ -0,0 +1,151 @@
+//go:build linux
+
+package netmon
+
+import (
+	"encoding/binary"
+	"net"
+	"syscall"
+	"time"
+)
+
+// netlinkWatcher watches RTNLGRP_LINK, RTNLGRP_IPV4_IFADDR and
+// RTNLGRP_IPV6_IFADDR via an AF_NETLINK/NETLINK_ROUTE socket.
+type netlinkWatcher struct {
+	fd     int
+	events chan Event
+	done   chan struct{}
+}
+
+// Legacy multicast group bitmask values for sockaddr_nl.nl_groups, i.e.
+// RTMGRP_LINK, RTMGRP_IPV4_IFADDR and RTMGRP_IPV6_IFADDR from
+// <linux/rtnetlink.h>: bit (RTNLGRP_x - 1), not the RTNLGRP_x group number
+// itself. The syscall package exposes neither form, so they're spelled out
+// here.
+const (
+	rtmGroupLink       = 1 << 0 // RTNLGRP_LINK (1)
+	rtmGroupIPv4IfAddr = 1 << 4 // RTNLGRP_IPV4_IFADDR (5)
+	rtmGroupIPv6IfAddr = 1 << 8 // RTNLGRP_IPV6_IFADDR (9)
+)
+
+// NewWatcher opens a netlink route socket and starts watching for
+// interface and address changes.
+func NewWatcher() (Watcher, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW, syscall.NETLINK_ROUTE)
+	if err != nil {
+		return nil, err
+	}
+
+	sa := &syscall.SockaddrNetlink{
+		Family: syscall.AF_NETLINK,
+		Groups: rtmGroupLink | rtmGroupIPv4IfAddr | rtmGroupIPv6IfAddr,
+	}
+	if err := syscall.Bind(fd, sa); err != nil {
+		_ = syscall.Close(fd)
+		return nil, err
+	}
+
+	w := &netlinkWatcher{
+		fd:     fd,
+		events: make(chan Event, 16),
+		done:   make(chan struct{}),
+	}
+	go w.run()
+
+	return w, nil
+}
+
+func (w *netlinkWatcher) Events() <-chan Event {
+	return w.events
+}
+
+func (w *netlinkWatcher) Close() error {
+	close(w.done)
+	return syscall.Close(w.fd)
+}
+
+func (w *netlinkWatcher) run() {
+	defer close(w.events)
+
+	const retryDelay = 100 * time.Millisecond
+
+	buf := make([]byte, syscall.Getpagesize())
+	for {
+		n, _, err := syscall.Recvfrom(w.fd, buf, 0)
+		if err != nil {
+			select {
+			case <-w.done:
+				return
+			case <-time.After(retryDelay):
+				continue
+			}
+		}
+
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			continue
+		}
+
+		for _, msg := range msgs {
+			ev, ok := parseMessage(msg)
+			if !ok {
+				continue
+			}
+			select {
+			case w.events <- ev:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+// parseMessage maps a single netlink message to an Event. Full attribute
+// parsing (ifa_cacheinfo, interface name lookups beyond Iface) is left
+// minimal on purpose: callers mainly care that *something* changed so they
+// can re-check net.InterfaceAddrs themselves.
+func parseMessage(msg syscall.NetlinkMessage) (Event, bool) {
+	switch msg.Header.Type {
+	case syscall.RTM_NEWLINK, syscall.RTM_DELLINK:
+		return Event{Type: LinkChange}, true
+	case syscall.RTM_NEWADDR:
+		return eventFromAddrMsg(AddrAdd, msg)
+	case syscall.RTM_DELADDR:
+		return eventFromAddrMsg(AddrDel, msg)
+	default:
+		return Event{}, false
+	}
+}
+
+func eventFromAddrMsg(t EventType, msg syscall.NetlinkMessage) (Event, bool) {
+	if len(msg.Data) < 8 {
+		return Event{}, false
+	}
+
+	// struct ifaddrmsg is {family, prefixlen, flags, scope} as four u8
+	// fields followed by a native-endian u32 ifa_index.
+	ifaIndex := binary.NativeEndian.Uint32(msg.Data[4:8])
+	iface, err := net.InterfaceByIndex(int(ifaIndex))
+	name := ""
+	if err == nil {
+		name = iface.Name
+	}
+
+	attrs, err := syscall.ParseNetlinkRouteAttr(&msg)
+	if err != nil {
+		return Event{Type: t, Iface: name}, true
+	}
+
+	for _, attr := range attrs {
+		const ifaAddress = 1
+		if attr.Attr.Type != ifaAddress {
+			continue
+		}
+		switch len(attr.Value) {
+		case net.IPv4len, net.IPv6len:
+			return Event{Type: t, Iface: name, Addr: &net.IPAddr{IP: net.IP(attr.Value)}}, true
+		}
+	}
+
+	return Event{Type: t, Iface: name}, true
+}
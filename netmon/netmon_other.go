@@ -0,0 +1,96 @@
+//go:build !linux
+
+package netmon
+
+import (
+	"net"
+	"time"
+)
+
+// pollInterval is how often non-Linux watchers re-read net.InterfaceAddrs.
+const pollInterval = 2 * time.Second
+
+// pollWatcher polls net.InterfaceAddrs on platforms without a netlink-style
+// change notification API.
+type pollWatcher struct {
+	events chan Event
+	done   chan struct{}
+}
+
+// NewWatcher starts polling the local interface address set and reports
+// additions and removals as Events.
+func NewWatcher() (Watcher, error) {
+	w := &pollWatcher{
+		events: make(chan Event, 16),
+		done:   make(chan struct{}),
+	}
+	go w.run()
+
+	return w, nil
+}
+
+func (w *pollWatcher) Events() <-chan Event {
+	return w.events
+}
+
+func (w *pollWatcher) Close() error {
+	close(w.done)
+	return nil
+}
+
+func (w *pollWatcher) run() {
+	defer close(w.events)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	prev := snapshot()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		}
+
+		cur := snapshot()
+		for addr := range cur {
+			if !prev[addr] {
+				w.emit(Event{Type: AddrAdd, Addr: &net.IPAddr{IP: net.ParseIP(addr)}})
+			}
+		}
+		for addr := range prev {
+			if !cur[addr] {
+				w.emit(Event{Type: AddrDel, Addr: &net.IPAddr{IP: net.ParseIP(addr)}})
+			}
+		}
+		prev = cur
+	}
+}
+
+func (w *pollWatcher) emit(ev Event) {
+	select {
+	case w.events <- ev:
+	case <-w.done:
+	}
+}
+
+// snapshot returns the set of IP strings currently bound to a local
+// interface.
+func snapshot() map[string]bool {
+	set := map[string]bool{}
+
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return set
+	}
+
+	for _, a := range addrs {
+		ipNet, ok := a.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		set[ipNet.IP.String()] = true
+	}
+
+	return set
+}
@@ -0,0 +1,124 @@
+package util
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Level is the severity of a single log event.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String returns the lower-case name of the level, as used in log output.
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// Fields carries the structured context attached to a single log event,
+// e.g. Fields{"local_addr": conn.LocalAddr(), "event": "stale_drop"}.
+type Fields map[string]interface{}
+
+// Logger is the structured, leveled logging interface used throughout the
+// util package. The zero value of any implementing type must not be used;
+// construct one with NewDefaultLogger or inject a logrus/zap-backed
+// implementation via SetLogger.
+type Logger interface {
+	Debug(msg string, fields Fields)
+	Info(msg string, fields Fields)
+	Warn(msg string, fields Fields)
+	Error(msg string, fields Fields)
+}
+
+// stdLogger is the default Logger, built on the standard library "log"
+// package. It is used whenever no logger has been injected via SetLogger.
+type stdLogger struct {
+	level Level
+	out   *log.Logger
+}
+
+// NewDefaultLogger returns a Logger that writes leveled, structured lines to
+// stderr. Events below minLevel are discarded.
+func NewDefaultLogger(minLevel Level) Logger {
+	return &stdLogger{
+		level: minLevel,
+		out:   log.New(os.Stderr, "", log.LstdFlags),
+	}
+}
+
+func (l *stdLogger) log(level Level, msg string, fields Fields) {
+	if level < l.level {
+		return
+	}
+	_ = l.out.Output(3, formatEvent(level, msg, fields))
+}
+
+func (l *stdLogger) Debug(msg string, fields Fields) { l.log(LevelDebug, msg, fields) }
+func (l *stdLogger) Info(msg string, fields Fields)  { l.log(LevelInfo, msg, fields) }
+func (l *stdLogger) Warn(msg string, fields Fields)  { l.log(LevelWarn, msg, fields) }
+func (l *stdLogger) Error(msg string, fields Fields) { l.log(LevelError, msg, fields) }
+
+// formatEvent renders a level, message and field set as a single logfmt-ish
+// line, with fields sorted by key so output is stable and easy to grep.
+func formatEvent(level Level, msg string, fields Fields) string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("level=")
+	b.WriteString(level.String())
+	b.WriteString(" msg=\"")
+	b.WriteString(msg)
+	b.WriteString("\"")
+	for _, k := range keys {
+		b.WriteString(" ")
+		b.WriteString(k)
+		b.WriteString("=")
+		fmt.Fprintf(&b, "%v", fields[k])
+	}
+
+	return b.String()
+}
+
+var (
+	loggerMu      sync.RWMutex
+	packageLogger Logger = NewDefaultLogger(LevelInfo)
+)
+
+// SetLogger replaces the package-level Logger used by PairConn and the PSK
+// helpers, letting the server/client subsystems route util's events into
+// their own logrus/zap pipeline. Safe for concurrent use.
+func SetLogger(l Logger) {
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	packageLogger = l
+}
+
+func currentLogger() Logger {
+	loggerMu.RLock()
+	defer loggerMu.RUnlock()
+	return packageLogger
+}
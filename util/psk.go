@@ -0,0 +1,304 @@
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// PSKEntry is a single key in a PSKStore's rotation schedule. A zero
+// NotBefore/NotAfter means "no bound" on that side of the window.
+type PSKEntry struct {
+	ID        []byte
+	Key       []byte
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+// validAt reports whether e may be used at instant t.
+func (e PSKEntry) validAt(t time.Time) bool {
+	if !e.NotBefore.IsZero() && t.Before(e.NotBefore) {
+		return false
+	}
+	if !e.NotAfter.IsZero() && t.After(e.NotAfter) {
+		return false
+	}
+	return true
+}
+
+// PSKStore holds an ordered set of PSKEntry values, oldest to newest by
+// NotBefore, and supports graceful rotation: the server accepts any entry
+// whose validity window covers "now", and the client offers the newest
+// still-valid entry's ID as the DTLS PSK identity hint.
+type PSKStore struct {
+	mu      sync.RWMutex
+	entries []PSKEntry
+}
+
+// NewPSKStore returns a PSKStore seeded with entries.
+func NewPSKStore(entries ...PSKEntry) *PSKStore {
+	s := &PSKStore{}
+	s.Replace(entries)
+	return s
+}
+
+// Replace atomically swaps the store's entries for a new set. Used on
+// SIGHUP reload and by LoadPSKFile.
+func (s *PSKStore) Replace(entries []PSKEntry) {
+	sorted := append([]PSKEntry(nil), entries...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].NotBefore.Before(sorted[j].NotBefore)
+	})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = sorted
+}
+
+// Entries returns a copy of the store's entries, oldest to newest.
+func (s *PSKStore) Entries() []PSKEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]PSKEntry(nil), s.entries...)
+}
+
+// Lookup returns the entry whose ID matches id and whose validity window
+// covers now, for use in a DTLS PSK callback.
+func (s *PSKStore) Lookup(id []byte, now time.Time) (PSKEntry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, e := range s.entries {
+		if bytes.Equal(e.ID, id) && e.validAt(now) {
+			return e, true
+		}
+	}
+	return PSKEntry{}, false
+}
+
+// Current returns the newest entry whose validity window covers now, for
+// the client to offer as its PSK identity hint.
+func (s *PSKStore) Current(now time.Time) (PSKEntry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		if s.entries[i].validAt(now) {
+			return s.entries[i], true
+		}
+	}
+	return PSKEntry{}, false
+}
+
+// PSKCallback returns a func(hint []byte) ([]byte, error) suitable for a
+// DTLS config's PSK callback. It treats hint as a key ID and returns the
+// matching key if its validity window covers the current time, logging
+// which ID matched.
+func (s *PSKStore) PSKCallback() func(hint []byte) ([]byte, error) {
+	return func(hint []byte) ([]byte, error) {
+		entry, ok := s.Lookup(hint, time.Now())
+		if !ok {
+			return nil, fmt.Errorf("no valid psk for identity hint %x", hint)
+		}
+
+		currentLogger().Info("psk matched", Fields{
+			"event":  "psk_matched",
+			"psk_id": hex.EncodeToString(entry.ID),
+		})
+
+		return entry.Key, nil
+	}
+}
+
+// IdentityHint returns the newest still-valid entry's ID, for the client
+// to offer as its DTLS PSK identity hint.
+func (s *PSKStore) IdentityHint() ([]byte, error) {
+	entry, ok := s.Current(time.Now())
+	if !ok {
+		return nil, fmt.Errorf("no valid psk available")
+	}
+	return entry.ID, nil
+}
+
+// Rotate generates a new key with a fresh random identity, extends the
+// validity of whatever entries are valid right now by overlap (so
+// in-flight clients and servers aren't kicked out mid-rotation), and adds
+// the new entry as the newest. It does not persist the store; call
+// SaveFile afterwards.
+func (s *PSKStore) Rotate(keyLength, idLength int, overlap time.Duration) (PSKEntry, error) {
+	id, err := GenPSK(idLength)
+	if err != nil {
+		return PSKEntry{}, fmt.Errorf("generate psk id: %w", err)
+	}
+	key, err := GenPSK(keyLength)
+	if err != nil {
+		return PSKEntry{}, fmt.Errorf("generate psk key: %w", err)
+	}
+
+	now := time.Now()
+	entry := PSKEntry{ID: id, Key: key, NotBefore: now}
+
+	s.mu.Lock()
+	for i, e := range s.entries {
+		if e.validAt(now) {
+			s.entries[i].NotAfter = now.Add(overlap)
+		}
+	}
+	s.entries = append(s.entries, entry)
+	sort.Slice(s.entries, func(i, j int) bool {
+		return s.entries[i].NotBefore.Before(s.entries[j].NotBefore)
+	})
+	s.mu.Unlock()
+
+	currentLogger().Info("psk rotated", Fields{
+		"event":  "psk_rotated",
+		"psk_id": hex.EncodeToString(id),
+	})
+
+	return entry, nil
+}
+
+// WatchFile reloads the store from path whenever SIGHUP is received or
+// interval elapses (if interval > 0), until stop is closed.
+func (s *PSKStore) WatchFile(path string, interval time.Duration, stop <-chan struct{}) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	reload := func() {
+		fresh, err := LoadPSKFile(path)
+		if err != nil {
+			currentLogger().Error("psk reload failed", Fields{
+				"event": "psk_reload_error",
+				"path":  path,
+				"err":   err,
+			})
+			return
+		}
+		s.Replace(fresh.Entries())
+		currentLogger().Info("psk store reloaded", Fields{
+			"event": "psk_reloaded",
+			"path":  path,
+		})
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-sighup:
+			reload()
+		case <-tick:
+			reload()
+		}
+	}
+}
+
+// LoadPSKFile reads a PSKStore from path. Each non-empty, non-comment line
+// is "id_hex key_hex [not_before] [not_after]", where not_before/not_after
+// are RFC 3339 timestamps or "-" for unbounded.
+func LoadPSKFile(path string) (*PSKStore, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open psk file: %w", err)
+	}
+	defer f.Close()
+
+	var entries []PSKEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		entry, err := parsePSKLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("parse psk file %s: %w", path, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read psk file %s: %w", path, err)
+	}
+
+	return NewPSKStore(entries...), nil
+}
+
+func parsePSKLine(line string) (PSKEntry, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return PSKEntry{}, fmt.Errorf("want at least id and key, got %q", line)
+	}
+
+	id, err := hex.DecodeString(fields[0])
+	if err != nil {
+		return PSKEntry{}, fmt.Errorf("decode id: %w", err)
+	}
+	key, err := hex.DecodeString(fields[1])
+	if err != nil {
+		return PSKEntry{}, fmt.Errorf("decode key: %w", err)
+	}
+
+	entry := PSKEntry{ID: id, Key: key}
+
+	if len(fields) > 2 && fields[2] != "-" {
+		t, err := time.Parse(time.RFC3339, fields[2])
+		if err != nil {
+			return PSKEntry{}, fmt.Errorf("parse not_before: %w", err)
+		}
+		entry.NotBefore = t
+	}
+
+	if len(fields) > 3 && fields[3] != "-" {
+		t, err := time.Parse(time.RFC3339, fields[3])
+		if err != nil {
+			return PSKEntry{}, fmt.Errorf("parse not_after: %w", err)
+		}
+		entry.NotAfter = t
+	}
+
+	return entry, nil
+}
+
+// SaveFile writes the store's entries back to path in the format
+// LoadPSKFile reads, oldest to newest.
+func (s *PSKStore) SaveFile(path string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var b strings.Builder
+	for _, e := range s.entries {
+		notBefore := "-"
+		if !e.NotBefore.IsZero() {
+			notBefore = e.NotBefore.Format(time.RFC3339)
+		}
+		notAfter := "-"
+		if !e.NotAfter.IsZero() {
+			notAfter = e.NotAfter.Format(time.RFC3339)
+		}
+		fmt.Fprintf(&b, "%s %s %s %s\n", hex.EncodeToString(e.ID), hex.EncodeToString(e.Key), notBefore, notAfter)
+	}
+
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		return fmt.Errorf("write psk file %s: %w", path, err)
+	}
+
+	return nil
+}
+
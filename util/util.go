@@ -4,7 +4,6 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
-	"log"
 	"net"
 	"sync"
 	"sync/atomic"
@@ -15,6 +14,10 @@ func GenPSK(length int) ([]byte, error) {
 	b := make([]byte, length)
 	_, err := rand.Read(b)
 	if err != nil {
+		currentLogger().Error("psk generation failed", Fields{
+			"event": "psk_gen_error",
+			"err":   err,
+		})
 		return nil, fmt.Errorf("random bytes generation failed: %w", err)
 	}
 
@@ -24,6 +27,10 @@ func GenPSK(length int) ([]byte, error) {
 func GenPSKHex(length int) (string, error) {
 	b, err := GenPSK(length)
 	if err != nil {
+		currentLogger().Error("psk hex generation failed", Fields{
+			"event": "psk_gen_error",
+			"err":   err,
+		})
 		return "", fmt.Errorf("can't generate hex key: %w", err)
 	}
 
@@ -56,19 +63,176 @@ const (
 	MaxPktBuf = 65536
 )
 
+// defaultBufPool is the package-level pool used by PairConn when callers
+// don't supply their own via PairConnOptions.BufPool.
+var defaultBufPool = NewBufPool(MaxPktBuf)
+
+// NewBufPool returns a sync.Pool of *[]byte, each sized to at least
+// MaxPktBuf. PairConn reads into these buffers from a packet-oriented
+// net.Conn (DTLS over UDP): a buffer shorter than an incoming datagram
+// causes net.Conn.Read to silently truncate it, discarding the rest, so
+// size is never allowed below MaxPktBuf regardless of how small the DTLS
+// records you expect to see actually are.
+func NewBufPool(size int) *sync.Pool {
+	if size < MaxPktBuf {
+		size = MaxPktBuf
+	}
+
+	return &sync.Pool{
+		New: func() interface{} {
+			b := make([]byte, size)
+			return &b
+		},
+	}
+}
+
+// PairConnOptions customizes PairConnWithOptions. The zero value selects
+// the package defaults: the shared MaxPktBuf-sized buffer pool and no
+// active heartbeat.
+type PairConnOptions struct {
+	// BufPool supplies the []byte buffers copier goroutines acquire on
+	// entry and release on exit. If nil, a shared pool sized to
+	// MaxPktBuf is used.
+	BufPool *sync.Pool
+
+	// Heartbeat, if set, enables an active keepalive probe on top of the
+	// passive idle timeout. See HeartbeatConfig. Its probes are written to
+	// left directly, concurrently with the copier goroutine relaying
+	// right into left, so left must tolerate concurrent writes from two
+	// goroutines (true of *net.UDPConn and pion/dtls's Conn, which both
+	// serialize writes internally, but not guaranteed for an arbitrary
+	// net.Conn).
+	Heartbeat *HeartbeatConfig
+
+	// Cancel, if set, lets an external subsystem (e.g. netmon noticing the
+	// local address vanished) tear the pair down immediately instead of
+	// waiting for idleTimeout. Closing the channel is equivalent to
+	// closing both left and right.
+	Cancel <-chan struct{}
+
+	// Metrics, if set, receives a SessionStats snapshot once the pair
+	// closes. See MetricsSink.
+	Metrics MetricsSink
+}
+
+// SessionStats summarizes one PairConn session's activity, reported to a
+// MetricsSink when the pair closes.
+type SessionStats struct {
+	LocalAddr  net.Addr
+	RemoteAddr net.Addr
+	Duration   time.Duration
+
+	BytesLeftToRight   int64
+	BytesRightToLeft   int64
+	PacketsLeftToRight int64
+	PacketsRightToLeft int64
+
+	StaleDrops  int64
+	TempErrors  int64
+	WriteErrors int64
+}
+
+// MetricsSink receives per-session counters from PairConn. Implementations
+// can export them as Prometheus metrics, push them to statsd/OTel, or
+// anything else; see the metrics package for a Prometheus-compatible one.
+type MetricsSink interface {
+	ObserveSession(stats SessionStats)
+}
+
+// sessionCounters accumulates the atomics copier updates for both
+// directions of a pair over its lifetime.
+type sessionCounters struct {
+	bytesLeftToRight   atomic.Int64
+	bytesRightToLeft   atomic.Int64
+	packetsLeftToRight atomic.Int64
+	packetsRightToLeft atomic.Int64
+	staleDrops         atomic.Int64
+	tempErrors         atomic.Int64
+	writeErrors        atomic.Int64
+}
+
+func (o PairConnOptions) bufPool() *sync.Pool {
+	if o.BufPool != nil {
+		return o.BufPool
+	}
+	return defaultBufPool
+}
+
+// HeartbeatConfig enables an active, application-level keepalive on a
+// PairConn pair so that a silent-but-alive NAT mapping isn't mistaken for a
+// stale one. When real traffic is flowing, the LSN counter already driven
+// by copier advances and probes are suppressed; only a genuinely idle pair
+// gets probed.
+type HeartbeatConfig struct {
+	// Interval is how often a probe is sent while the pair is otherwise
+	// idle. It should be shorter than the idleTimeout passed to
+	// PairConnWithOptions, or the passive timeout will win the race.
+	Interval time.Duration
+
+	// Timeout is how long to wait, after sending a probe, for any
+	// activity (a reply or unrelated traffic) before counting it missed.
+	Timeout time.Duration
+
+	// Payload is the probe written to the connection. Callers are
+	// responsible for choosing a payload their protocol on the other end
+	// recognizes and echoes or otherwise reacts to.
+	//
+	// The write happens on its own goroutine, concurrently with whatever
+	// else is writing to the same conn (see PairConnOptions.Heartbeat), so
+	// the conn passed to PairConnWithOptions must itself be safe for
+	// concurrent writes from two goroutines.
+	Payload []byte
+
+	// OnMissed, if set, is called after each individual missed probe,
+	// before MaxMissed consecutive misses finally close the pair.
+	OnMissed func()
+
+	// MaxMissed is the number of consecutive missed probes tolerated
+	// before the pair is closed. Defaults to 3 if zero.
+	MaxMissed int
+}
+
+// PairConn relays traffic between left and right until one side closes or
+// idleTimeout elapses with no activity. It is equivalent to
+// PairConnWithOptions with the zero value of PairConnOptions.
 func PairConn(left, right net.Conn, idleTimeout time.Duration) {
+	PairConnWithOptions(left, right, idleTimeout, PairConnOptions{})
+}
+
+// PairConnWithOptions relays traffic between left and right until one side
+// closes or idleTimeout elapses with no activity, using opts to customize
+// buffer pooling. It reuses the calling goroutine for one direction and
+// spawns a single child goroutine for the other.
+//
+// The pool removes the dominant cost from the original implementation, a
+// MaxPktBuf-sized make([]byte, ...) per direction per session, but the
+// call still allocates the bookkeeping a session needs regardless of
+// buffer strategy (the done channel synchronizing the two directions, the
+// copier closures, and - only when Heartbeat or Cancel is set - a stop
+// channel for their goroutines). It is pooled, not allocation-free.
+func PairConnWithOptions(left, right net.Conn, idleTimeout time.Duration, opts PairConnOptions) {
 	var lsn atomic.Int32
-	var wg sync.WaitGroup
+	var counters sessionCounters
+	start := time.Now()
 
-	copier := func(dst, src net.Conn) {
-		defer wg.Done()
+	logger := currentLogger()
+	pool := opts.bufPool()
+
+	copier := func(dst, src net.Conn, bytesCtr, packetsCtr *atomic.Int64) {
 		defer dst.Close()
-		buf := make([]byte, MaxPktBuf)
+		bufp := pool.Get().(*[]byte)
+		defer pool.Put(bufp)
+		buf := *bufp
 		for {
 			oldLSN := lsn.Load()
 
 			if err := src.SetReadDeadline(time.Now().Add(idleTimeout)); err != nil {
-				log.Printf("can't update deadline for connection: %v", err)
+				logger.Error("can't update read deadline", Fields{
+					"event":       "deadline_error",
+					"local_addr":  src.LocalAddr(),
+					"remote_addr": src.RemoteAddr(),
+					"err":         err,
+				})
 				break
 			}
 
@@ -80,31 +244,181 @@ func PairConn(left, right net.Conn, idleTimeout time.Duration) {
 						// not stale conn
 						continue
 					} else {
-						log.Printf("dropping stale connection %s <=> %s", src.LocalAddr(), src.RemoteAddr())
+						counters.staleDrops.Add(1)
+						logger.Info("dropping stale connection", Fields{
+							"event":       "stale_drop",
+							"local_addr":  src.LocalAddr(),
+							"remote_addr": src.RemoteAddr(),
+						})
 					}
 				} else {
 					// any other error
 					if isTemporary(err) {
-						log.Printf("ignoring temporary error during read from %s: %v", src.RemoteAddr(), err)
+						counters.tempErrors.Add(1)
+						logger.Warn("ignoring temporary error during read", Fields{
+							"event":       "temp_error",
+							"remote_addr": src.RemoteAddr(),
+							"err":         err,
+						})
 						continue
 					}
-					log.Printf("read from %s error: %v", src.RemoteAddr(), err)
+					logger.Error("read error", Fields{
+						"event":       "read_error",
+						"remote_addr": src.RemoteAddr(),
+						"err":         err,
+					})
 				}
 				break
 			}
 
 			lsn.Add(1)
+			bytesCtr.Add(int64(n))
+			packetsCtr.Add(1)
 
 			_, err = dst.Write(buf[:n])
 			if err != nil {
-				log.Printf("write to %s error: %v", dst.RemoteAddr(), err)
+				counters.writeErrors.Add(1)
+				logger.Error("write error", Fields{
+					"event":       "write_error",
+					"remote_addr": dst.RemoteAddr(),
+					"bytes":       n,
+					"err":         err,
+				})
 				break
 			}
 		}
 	}
 
-	wg.Add(2)
-	go copier(left, right)
-	go copier(right, left)
-	wg.Wait()
+	// stop is only needed to unwind the heartbeat and/or cancel-watcher
+	// goroutines below; skip the allocation entirely when neither is
+	// configured; the common case, and the one the benchmarks measure.
+	var stop chan struct{}
+	if opts.Heartbeat != nil || opts.Cancel != nil {
+		stop = make(chan struct{})
+	}
+
+	if hb := opts.Heartbeat; hb != nil {
+		go runHeartbeat(left, &lsn, *hb, logger, stop)
+	}
+
+	if opts.Cancel != nil {
+		go func() {
+			select {
+			case <-opts.Cancel:
+				logger.Info("closing pair on external cancel", Fields{
+					"event":       "external_cancel",
+					"local_addr":  left.LocalAddr(),
+					"remote_addr": left.RemoteAddr(),
+				})
+				left.Close()
+				right.Close()
+			case <-stop:
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		copier(left, right, &counters.bytesLeftToRight, &counters.packetsLeftToRight)
+	}()
+	copier(right, left, &counters.bytesRightToLeft, &counters.packetsRightToLeft)
+	<-done
+	if stop != nil {
+		close(stop)
+	}
+
+	if opts.Metrics != nil {
+		opts.Metrics.ObserveSession(SessionStats{
+			LocalAddr:          left.LocalAddr(),
+			RemoteAddr:         left.RemoteAddr(),
+			Duration:           time.Since(start),
+			BytesLeftToRight:   counters.bytesLeftToRight.Load(),
+			BytesRightToLeft:   counters.bytesRightToLeft.Load(),
+			PacketsLeftToRight: counters.packetsLeftToRight.Load(),
+			PacketsRightToLeft: counters.packetsRightToLeft.Load(),
+			StaleDrops:         counters.staleDrops.Load(),
+			TempErrors:         counters.tempErrors.Load(),
+			WriteErrors:        counters.writeErrors.Load(),
+		})
+	}
+}
+
+// runHeartbeat sends hb.Payload on conn whenever lsn hasn't advanced since
+// the last probe, and escalates through hb.OnMissed when no activity
+// follows within hb.Timeout, finally closing conn after hb.MaxMissed
+// consecutive misses. It exits as soon as stop is closed.
+func runHeartbeat(conn net.Conn, lsn *atomic.Int32, hb HeartbeatConfig, logger Logger, stop <-chan struct{}) {
+	maxMissed := hb.MaxMissed
+	if maxMissed <= 0 {
+		maxMissed = 3
+	}
+
+	if hb.Interval <= 0 {
+		logger.Error("heartbeat disabled: non-positive interval", Fields{
+			"event": "heartbeat_config_error",
+		})
+		return
+	}
+
+	ticker := time.NewTicker(hb.Interval)
+	defer ticker.Stop()
+
+	missed := 0
+	lastLSN := lsn.Load()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		if cur := lsn.Load(); cur != lastLSN {
+			// real traffic arrived since the last probe; suppress it
+			lastLSN = cur
+			missed = 0
+			continue
+		}
+
+		if _, err := conn.Write(hb.Payload); err != nil {
+			logger.Warn("heartbeat probe write failed", Fields{
+				"event":       "heartbeat_write_error",
+				"remote_addr": conn.RemoteAddr(),
+				"err":         err,
+			})
+			continue
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(hb.Timeout):
+		}
+
+		if cur := lsn.Load(); cur != lastLSN {
+			lastLSN = cur
+			missed = 0
+			continue
+		}
+
+		missed++
+		logger.Warn("heartbeat probe missed", Fields{
+			"event":       "heartbeat_missed",
+			"remote_addr": conn.RemoteAddr(),
+			"missed":      missed,
+		})
+		if hb.OnMissed != nil {
+			hb.OnMissed()
+		}
+
+		if missed >= maxMissed {
+			logger.Error("heartbeat exhausted, closing pair", Fields{
+				"event":       "heartbeat_exhausted",
+				"remote_addr": conn.RemoteAddr(),
+			})
+			conn.Close()
+			return
+		}
+	}
 }
@@ -0,0 +1,86 @@
+package util
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// runPipedSession spins up a fresh net.Pipe-backed pair, drives relay with
+// relay, pushes one payload through it left-to-right, and tears the pair
+// down once the payload has been read out the other end. Each call is one
+// full session, so per-session allocations (e.g. the per-direction copy
+// buffers) land inside the benchmark loop they're measured in.
+func runPipedSession(payload []byte, relay func(left, right net.Conn, idleTimeout time.Duration)) {
+	left, leftPeer := net.Pipe()
+	right, rightPeer := net.Pipe()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		relay(left, right, time.Second)
+	}()
+
+	_, _ = leftPeer.Write(payload)
+	buf := make([]byte, len(payload))
+	_, _ = rightPeer.Read(buf)
+
+	leftPeer.Close()
+	rightPeer.Close()
+	<-done
+}
+
+// pairConnUnpooled reproduces PairConn's pre-sync.Pool behavior: a fresh
+// make([]byte, MaxPktBuf) per direction per session. It exists only as the
+// "before" baseline for BenchmarkPairConnUnpooled.
+func pairConnUnpooled(left, right net.Conn, idleTimeout time.Duration) {
+	var wg sync.WaitGroup
+
+	copier := func(dst, src net.Conn) {
+		defer wg.Done()
+		defer dst.Close()
+		buf := make([]byte, MaxPktBuf)
+		for {
+			if err := src.SetReadDeadline(time.Now().Add(idleTimeout)); err != nil {
+				return
+			}
+			n, err := src.Read(buf)
+			if err != nil {
+				return
+			}
+			if _, err := dst.Write(buf[:n]); err != nil {
+				return
+			}
+		}
+	}
+
+	wg.Add(2)
+	go copier(left, right)
+	go copier(right, left)
+	wg.Wait()
+}
+
+// BenchmarkPairConnUnpooled is the "before" baseline: one MaxPktBuf buffer
+// allocated per direction, per session, with no pooling.
+func BenchmarkPairConnUnpooled(b *testing.B) {
+	payload := make([]byte, 1024)
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		runPipedSession(payload, pairConnUnpooled)
+	}
+}
+
+// BenchmarkPairConn is the "after" case: PairConn acquiring its copy
+// buffers from the package-level sync.Pool instead of allocating them.
+func BenchmarkPairConn(b *testing.B) {
+	payload := make([]byte, 1024)
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		runPipedSession(payload, PairConn)
+	}
+}